@@ -10,11 +10,22 @@ import (
 type Client struct {
 	jsonClient     *JsonClient
 	extraGenerator ExtraGenerator
-	catcher        chan *Response
+	inbound        chan *Response
+	fanout         chan Type
 	listenerStore  *listenerStore
 	catchersStore  *sync.Map
 	updatesTimeout time.Duration
 	catchTimeout   time.Duration
+	invokeMu       sync.RWMutex
+	interceptors   []Interceptor
+	invoke         Invoker
+	metrics        *metrics
+	reaperInterval time.Duration
+	reaperGrace    time.Duration
+	done           chan struct{}
+	sessionStore   SessionStore
+	sessionKey     string
+	dbDirectory    string
 }
 
 type Option func(*Client)
@@ -50,133 +61,173 @@ func WithLogVerbosity(req *SetLogVerbosityLevelRequest) Option {
 }
 
 func NewClient(options ...Option) (*Client, error) {
-	catchersListener := make(chan *Response, 1000)
-
 	client := &Client{
 		jsonClient:    NewJsonClient(),
-		catcher:       catchersListener,
+		inbound:       make(chan *Response, inboundQueueSize),
+		fanout:        make(chan Type, fanoutQueueSize),
 		listenerStore: newListenerStore(),
 		catchersStore: &sync.Map{},
+		done:          make(chan struct{}),
 	}
 
 	client.extraGenerator = UuidV4Generator()
 	client.catchTimeout = 60 * time.Second
 	client.updatesTimeout = 60 * time.Second
+	client.reaperInterval = defaultReaperInterval
+	client.reaperGrace = defaultReaperGrace
 
 	for _, option := range options {
 		option(client)
 	}
 
-	go receive(client)
-	go client.catch(catchersListener)
+	if client.invoke == nil {
+		client.invoke = client.buildInvoker()
+	}
+
+	go client.processInbound()
+	go client.fanOutListeners()
+	go client.reapStaleCatchers()
+	globalReceiver.register(client)
 
 	return client, nil
 }
 
+// Auth drives the authorization flow via authHandler. If a SessionStore,
+// session key and session directory were configured (WithSessionStore,
+// WithSessionKey, WithSessionDirectory), Auth restores a checkpointed
+// tdlib_db tarball into the session directory before authorizing, and
+// uploads a fresh checkpoint once Authorize succeeds - see SessionStore's
+// doc comment for what this does and does not cover.
 func (client *Client) Auth(ctx context.Context, authHandler AuthorizationStateHandler) error {
-	return Authorize(ctx, client, authHandler)
-}
-
-var mutex = sync.RWMutex{}
-var receiveStarted = false
-var clients = make(map[int]*Client)
-
-func receive(client *Client) {
-	mutex.Lock()
-	_, ok := clients[client.jsonClient.id]
-	if !ok {
-		clients[client.jsonClient.id] = client
-	}
-	if receiveStarted == true {
-		//receiver already started in different thread
-		return
-	}
-	receiveStarted = true
-	mutex.Unlock()
-	for {
-		resp, err := Receive(client.updatesTimeout)
-		if err != nil {
-			continue
-		}
-		receivedClientId := resp.ClientId
-		mutex.RLock()
-		_, ok = clients[receivedClientId]
-		if !ok {
-			mutex.RUnlock()
-			continue
+	if client.checkpointsEnabled() {
+		if err := client.restoreSession(ctx); err != nil {
+			return err
 		}
+	}
 
-		receiverClient := clients[receivedClientId]
-
-		receiverClient.catcher <- resp
-
-		mutex.RUnlock()
-		typ, err := UnmarshalType(resp.Data)
-		if err != nil {
-			continue
-		}
+	if err := Authorize(ctx, client, authHandler); err != nil {
+		return err
+	}
 
-		needGc := false
-		for _, listener := range receiverClient.listenerStore.Listeners() {
-			if listener.IsActive() {
-				listener.Updates <- typ
-			} else {
-				needGc = true
-			}
-		}
-		if needGc {
-			receiverClient.listenerStore.gc()
-		}
+	if client.checkpointsEnabled() {
+		return client.checkpointSession(ctx)
 	}
+	return nil
 }
 
-func (client *Client) catch(updates chan *Response) {
-	for update := range updates {
-		if update.Extra != "" {
-			value, ok := client.catchersStore.Load(update.Extra)
-			if ok {
-				value.(chan *Response) <- update
-			}
-		}
-	}
+func (client *Client) checkpointsEnabled() bool {
+	return client.sessionStore != nil && client.sessionKey != "" && client.dbDirectory != ""
 }
 
 func (client *Client) Send(req Request) (*Response, error) {
+	return client.SendCtx(context.Background(), req)
+}
+
+// SendCtx behaves like Send but additionally honors ctx: if ctx is canceled
+// or its deadline is exceeded before TDLib replies, SendCtx returns ctx.Err()
+// and cleans up the catcher entry immediately instead of waiting for
+// catchTimeout to elapse. This lets callers tie a TDLib round-trip to the
+// lifetime of, for example, an incoming HTTP request.
+//
+// The call is routed through any interceptors registered via
+// WithInterceptors/Use, in registration order, with sendOnce as the
+// innermost Invoker.
+func (client *Client) SendCtx(ctx context.Context, req Request) (*Response, error) {
 	req.Extra = client.extraGenerator()
 
-	catcher := make(chan *Response, 1)
+	client.invokeMu.RLock()
+	invoke := client.invoke
+	client.invokeMu.RUnlock()
+
+	return invoke(ctx, req)
+}
+
+// sendOnce is the innermost Invoker: it sends req and waits for the
+// matching response, ctx cancellation, or the entry's deadline - re-read on
+// every wakeup so RefreshCatcher can actually extend an in-flight call
+// instead of the wait being pinned to a deadline snapshot. req.Extra must
+// already be set.
+func (client *Client) sendOnce(ctx context.Context, req Request) (*Response, error) {
+	deadline := time.Now().Add(client.catchTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	// entry.ch is never closed: processInbound or the reaper may be sending
+	// on it concurrently with us giving up below. Deleting from
+	// catchersStore is enough; the channel is left for the GC.
+	entry := &catcherEntry{
+		ch:           make(chan *Response, 1),
+		registeredAt: time.Now(),
+	}
+	entry.setDeadline(deadline)
 
-	client.catchersStore.Store(req.Extra, catcher)
+	client.catchersStore.Store(req.Extra, entry)
+	defer client.catchersStore.Delete(req.Extra)
 
-	defer func() {
-		client.catchersStore.Delete(req.Extra)
-		close(catcher)
-	}()
+	if client.metrics != nil {
+		client.metrics.catchersOutstanding.Inc()
+		defer client.metrics.catchersOutstanding.Dec()
+	}
 
 	client.jsonClient.Send(req)
 
-	ctx, cancel := context.WithTimeout(context.Background(), client.catchTimeout)
-	defer cancel()
+	for {
+		live, _ := entry.getDeadline()
+		timer := time.NewTimer(time.Until(live))
+
+		select {
+		case response := <-entry.ch:
+			timer.Stop()
+			if response == reapedResponse {
+				return nil, ErrCatcherReaped
+			}
+			return response, nil
 
-	select {
-	case response := <-catcher:
-		return response, nil
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
 
-	case <-ctx.Done():
-		return nil, errors.New("response catching timeout")
+		case <-timer.C:
+			// RefreshCatcher may have pushed the deadline out while this
+			// timer was running; check the live value before giving up.
+			if latest, _ := entry.getDeadline(); time.Now().Before(latest) {
+				continue
+			}
+			return nil, errors.New("response catching timeout")
+		}
 	}
 }
 
-func (client *Client) GetListener() *Listener {
-	listener := &Listener{
-		isActive: true,
-		Updates:  make(chan Type, 1000),
+// GetListener returns a Listener that receives a copy of every update the
+// Client processes. Without options it behaves exactly as before: a 1000
+// update buffer with a Block overflow policy. Pass ListenerOptions via the
+// With* ListenerOption helpers to filter updates or change what happens
+// when the consumer falls behind.
+func (client *Client) GetListener(opts ...ListenerOption) *Listener {
+	var options ListenerOptions
+	for _, opt := range opts {
+		opt(&options)
 	}
+
+	listener := newListener(options)
+	listener.metrics = client.metrics
 	client.listenerStore.Add(listener)
 
 	return listener
 }
 
+// Stop shuts the client down. If session checkpointing is configured, it
+// uploads a final checkpoint first, best-effort, so a replica restarting
+// against the same session directory picks up where this one left off.
 func (client *Client) Stop() {
+	if client.checkpointsEnabled() {
+		ctx, cancel := context.WithTimeout(context.Background(), checkpointShutdownTimeout)
+		_ = client.checkpointSession(ctx)
+		cancel()
+	}
+
+	close(client.done)
+	globalReceiver.unregister(client)
 	client.Destroy()
 }
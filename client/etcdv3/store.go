@@ -0,0 +1,120 @@
+// Package etcdv3 provides a client.SessionStore backed by etcd v3, the
+// building block for checkpointing TDLib session/auth artifacts somewhere
+// other than one replica's local disk so a bot can be handed off between
+// replicas for horizontal scaling.
+package etcdv3
+
+import (
+	"context"
+	"fmt"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+
+	"github.com/MichalWityk/go-tdlib/client"
+)
+
+const defaultLeaseTTL = 30
+
+// Store is a client.SessionStore backed by etcd v3. Every Put is written
+// under a lease tied to Store's session, so a replica that dies without
+// calling Delete does not leave a stale session behind forever, and is
+// guarded by a concurrency.Mutex scoped to the key so two replicas racing
+// to own the same TDLib account cannot clobber each other's checkpoint.
+type Store struct {
+	client   *clientv3.Client
+	session  *concurrency.Session
+	leaseTTL int
+}
+
+// Option configures a Store built by New.
+type Option func(*Store)
+
+// WithLeaseTTL sets the liveness lease TTL, in seconds, attached to every
+// key this Store writes. Defaults to 30.
+func WithLeaseTTL(seconds int) Option {
+	return func(store *Store) {
+		store.leaseTTL = seconds
+	}
+}
+
+// New builds a Store on top of an already-connected etcd client.
+func New(cli *clientv3.Client, opts ...Option) (*Store, error) {
+	store := &Store{client: cli, leaseTTL: defaultLeaseTTL}
+	for _, opt := range opts {
+		opt(store)
+	}
+
+	session, err := concurrency.NewSession(cli, concurrency.WithTTL(store.leaseTTL))
+	if err != nil {
+		return nil, fmt.Errorf("etcdv3: new session: %w", err)
+	}
+	store.session = session
+
+	return store, nil
+}
+
+// Close releases the Store's etcd session, and with it its lease.
+func (store *Store) Close() error {
+	return store.session.Close()
+}
+
+func (store *Store) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := store.client.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Kvs) == 0 {
+		return nil, client.ErrSessionNotFound
+	}
+	return resp.Kvs[0].Value, nil
+}
+
+// Put acquires a mutex scoped to key before writing, so a concurrent Put
+// for the same key from another replica waits rather than racing.
+func (store *Store) Put(ctx context.Context, key string, value []byte) error {
+	mutex := concurrency.NewMutex(store.session, key+"/lock")
+	if err := mutex.Lock(ctx); err != nil {
+		return fmt.Errorf("etcdv3: lock %s: %w", key, err)
+	}
+	defer mutex.Unlock(ctx)
+
+	_, err := store.client.Put(ctx, key, string(value), clientv3.WithLease(store.session.Lease()))
+	return err
+}
+
+func (store *Store) Delete(ctx context.Context, key string) error {
+	_, err := store.client.Delete(ctx, key)
+	return err
+}
+
+// Watch reports every Put/Delete under prefix as a client.Event until ctx
+// is canceled or the underlying etcd watch channel closes.
+func (store *Store) Watch(ctx context.Context, prefix string) (<-chan client.Event, error) {
+	watchCh := store.client.Watch(ctx, prefix, clientv3.WithPrefix())
+	events := make(chan client.Event)
+
+	go func() {
+		defer close(events)
+
+		for resp := range watchCh {
+			for _, ev := range resp.Events {
+				event := client.Event{Key: string(ev.Kv.Key)}
+				if ev.Type == clientv3.EventTypeDelete {
+					event.Type = client.EventDelete
+				} else {
+					event.Type = client.EventPut
+					event.Value = ev.Kv.Value
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
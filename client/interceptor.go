@@ -0,0 +1,180 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Invoker performs a single TDLib round-trip. It is the shape both
+// Client.SendCtx's core implementation and every Interceptor conform to,
+// so interceptors can be composed transparently around the real call.
+type Invoker func(ctx context.Context, req Request) (*Response, error)
+
+// Interceptor wraps an Invoker with cross-cutting behaviour (logging,
+// retries, rate limiting, ...) and returns the wrapped Invoker. Interceptors
+// are composed in registration order: the first interceptor passed to
+// WithInterceptors/Use is outermost, the innermost Invoker being the one
+// that actually sends the request and waits on the catcher.
+type Interceptor func(next Invoker) Invoker
+
+// WithInterceptors registers interceptors on the client being constructed.
+// It is equivalent to calling Use after NewClient returns.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(client *Client) {
+		client.Use(interceptors...)
+	}
+}
+
+// Use appends interceptors to the client's chain and rebuilds the pipeline
+// that Send/SendCtx route through. Interceptors added by a later call to Use
+// run inside of (are wrapped by) interceptors added earlier. Use is safe to call
+// concurrently with Send/SendCtx, including after NewClient has returned.
+func (client *Client) Use(interceptors ...Interceptor) {
+	client.invokeMu.Lock()
+	defer client.invokeMu.Unlock()
+
+	client.interceptors = append(client.interceptors, interceptors...)
+	client.invoke = client.buildInvoker()
+}
+
+func (client *Client) buildInvoker() Invoker {
+	invoker := Invoker(client.sendOnce)
+	for i := len(client.interceptors) - 1; i >= 0; i-- {
+		invoker = client.interceptors[i](invoker)
+	}
+	return invoker
+}
+
+// LoggingInterceptor logs every call's TDLib @type, @extra and latency.
+func LoggingInterceptor(logger *log.Logger) Interceptor {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, req Request) (*Response, error) {
+			start := time.Now()
+			resp, err := next(ctx, req)
+			logger.Printf("tdlib: type=%s extra=%s latency=%s err=%v", req.Type, req.Extra, time.Since(start), err)
+			return resp, err
+		}
+	}
+}
+
+// RecoveryInterceptor recovers a panic from any interceptor registered after
+// it, or from the final send, turning it into an error so a single
+// misbehaving interceptor cannot take down the caller's goroutine. Register
+// it first to cover the whole chain.
+func RecoveryInterceptor() Interceptor {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, req Request) (resp *Response, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("tdlib: recovered panic in interceptor chain: %v", r)
+				}
+			}()
+			return next(ctx, req)
+		}
+	}
+}
+
+// RateLimitInterceptor blocks until limiter admits the call or ctx is done,
+// bounding the rate at which requests are sent to TDLib.
+func RateLimitInterceptor(limiter *rate.Limiter) Interceptor {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, req Request) (*Response, error) {
+			if err := limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// RetryInterceptor retries a call when TDLib answers with a transient
+// "error" response (code 429, flood wait), backing off for the duration
+// TDLib reported (falling back to base, doubled per attempt, if it did not)
+// up to maxRetries times. It gives up early if ctx is done.
+//
+// Retrying resends req as-is, including its side effects - safe for an
+// idempotent call like getChat, not safe for one that isn't, like
+// sendMessage, which would be posted twice. retryable is called with the
+// request's @type before any retry is attempted and must return false for
+// anything that is not safe to resend; only requests it allows through are
+// ever retried.
+func RetryInterceptor(maxRetries int, base time.Duration, retryable func(reqType string) bool) Interceptor {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, req Request) (*Response, error) {
+			backoff := base
+
+			for attempt := 0; ; attempt++ {
+				resp, err := next(ctx, req)
+				if err != nil {
+					return resp, err
+				}
+
+				retryAfter, floodWaited := floodWait(resp)
+				if !floodWaited || !retryable(req.Type) || attempt >= maxRetries {
+					return resp, nil
+				}
+
+				wait := retryAfter
+				if wait <= 0 {
+					wait = backoff
+					backoff *= 2
+				}
+
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-ctx.Done():
+					timer.Stop()
+					return nil, ctx.Err()
+				}
+			}
+		}
+	}
+}
+
+// tdlibError reports whether resp is a TDLib "error" response, returning it
+// if so.
+func tdlibError(resp *Response) (*Error, bool) {
+	typ, err := UnmarshalType(resp.Data)
+	if err != nil {
+		return nil, false
+	}
+
+	tdlibErr, ok := typ.(*Error)
+	return tdlibErr, ok
+}
+
+// floodWait reports whether resp is a TDLib "error" response signalling a
+// transient, retryable condition (code 429), and how long TDLib asked the
+// caller to wait before retrying, if it said.
+func floodWait(resp *Response) (time.Duration, bool) {
+	tdlibErr, ok := tdlibError(resp)
+	if !ok || tdlibErr.Code != 429 {
+		return 0, false
+	}
+
+	return parseRetryAfter(tdlibErr.Message), true
+}
+
+// parseRetryAfter extracts the "retry after N" hint TDLib embeds in a flood
+// wait error message. It returns 0 if none is found, leaving the caller to
+// fall back to its own backoff schedule.
+func parseRetryAfter(message string) time.Duration {
+	const prefix = "Too Many Requests: retry after "
+	if !strings.HasPrefix(message, prefix) {
+		return 0
+	}
+
+	seconds, err := strconv.Atoi(strings.TrimSpace(message[len(prefix):]))
+	if err != nil {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,328 @@
+package client
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrListenerOverflow is the error returned by Listener.Err() when the
+// listener was closed because its Updates channel overflowed under a
+// ListenerOptions.Strict policy.
+var ErrListenerOverflow = errors.New("client: listener closed after overflow")
+
+// OverflowPolicy controls what a Listener does when Updates is full and a
+// new update needs to be delivered.
+type OverflowPolicy int
+
+const (
+	// Block waits for room in Updates. This is the default and matches
+	// the behavior of every Listener before ListenerOptions existed.
+	Block OverflowPolicy = iota
+	// DropNewest discards the incoming update, leaving everything already
+	// queued untouched.
+	DropNewest
+	// DropOldest discards the oldest queued update to make room for the
+	// incoming one.
+	DropOldest
+	// Coalesce keeps only the most recently received update per key, as
+	// computed by ListenerOptions.CoalesceKey, collapsing a burst of
+	// updates for the same key (e.g. the same chat) into one delivery.
+	Coalesce
+)
+
+// ListenerOptions configures a Listener returned by Client.GetListener.
+type ListenerOptions struct {
+	// BufferSize is the capacity of Listener.Updates. Defaults to 1000.
+	BufferSize int
+	// Overflow selects what happens once Updates is full. Defaults to
+	// Block.
+	Overflow OverflowPolicy
+	// Filter, if set, drops an update before Overflow is ever considered
+	// when it returns false.
+	Filter func(Type) bool
+	// CoalesceKey groups updates for the Coalesce policy, e.g. by
+	// "@type"+chat ID. Required when Overflow is Coalesce.
+	CoalesceKey func(Type) string
+	// Strict closes the listener (recording ErrListenerOverflow, readable
+	// via Err()) the first time DropNewest or DropOldest would otherwise
+	// drop an update, instead of dropping and continuing.
+	Strict bool
+}
+
+// ListenerOption applies one setting to a ListenerOptions being built by
+// Client.GetListener.
+type ListenerOption func(*ListenerOptions)
+
+func WithBufferSize(size int) ListenerOption {
+	return func(o *ListenerOptions) {
+		o.BufferSize = size
+	}
+}
+
+func WithOverflowPolicy(policy OverflowPolicy) ListenerOption {
+	return func(o *ListenerOptions) {
+		o.Overflow = policy
+	}
+}
+
+func WithListenerFilter(filter func(Type) bool) ListenerOption {
+	return func(o *ListenerOptions) {
+		o.Filter = filter
+	}
+}
+
+func WithCoalesceKey(key func(Type) string) ListenerOption {
+	return func(o *ListenerOptions) {
+		o.CoalesceKey = key
+	}
+}
+
+func WithStrictOverflow() ListenerOption {
+	return func(o *ListenerOptions) {
+		o.Strict = true
+	}
+}
+
+// Listener receives a copy of every update emitted by a Client, subject to
+// its configured OverflowPolicy once Updates is full.
+type Listener struct {
+	isActive bool
+	Updates  chan Type
+
+	options ListenerOptions
+
+	mu          sync.Mutex
+	err         error
+	dropped     uint64
+	coalesced   map[string]Type
+	pumpTrigger chan struct{}
+
+	metrics *metrics
+}
+
+func newListener(options ListenerOptions) *Listener {
+	if options.BufferSize <= 0 {
+		options.BufferSize = 1000
+	}
+
+	listener := &Listener{
+		isActive: true,
+		Updates:  make(chan Type, options.BufferSize),
+		options:  options,
+	}
+
+	if options.Overflow == Coalesce {
+		listener.coalesced = make(map[string]Type)
+		listener.pumpTrigger = make(chan struct{}, 1)
+		go listener.coalescePump()
+	}
+
+	return listener
+}
+
+func (listener *Listener) IsActive() bool {
+	listener.mu.Lock()
+	defer listener.mu.Unlock()
+
+	return listener.isActive
+}
+
+// Close deactivates the listener. A subsequent Client.listenerStore.gc()
+// call removes it from dispatch.
+func (listener *Listener) Close() {
+	listener.mu.Lock()
+	defer listener.mu.Unlock()
+
+	listener.deactivateLocked(nil)
+}
+
+// Err returns the reason the listener was closed, if it was closed due to
+// overflow under a Strict policy. It returns nil otherwise, including when
+// the listener was closed normally via Close.
+func (listener *Listener) Err() error {
+	listener.mu.Lock()
+	defer listener.mu.Unlock()
+
+	return listener.err
+}
+
+// Dropped returns the number of updates dropped for this listener so far
+// because Updates was full or, under Coalesce, because a newer update for
+// the same key replaced one still pending.
+func (listener *Listener) Dropped() uint64 {
+	listener.mu.Lock()
+	defer listener.mu.Unlock()
+
+	return listener.dropped
+}
+
+func (listener *Listener) deactivateLocked(err error) {
+	if !listener.isActive {
+		return
+	}
+	listener.isActive = false
+	listener.err = err
+	if listener.pumpTrigger != nil {
+		close(listener.pumpTrigger)
+	}
+	if listener.metrics != nil {
+		label := listenerLabel(listener)
+		listener.metrics.listenerQueueLength.DeleteLabelValues(label)
+		listener.metrics.listenerDropped.DeleteLabelValues(label)
+	}
+}
+
+// deliver routes typ to Updates according to the listener's OverflowPolicy.
+// It never blocks the caller (the client's fanOutListeners goroutine) for
+// longer than the listener's own Block policy explicitly asks for, and it is
+// the only path by which fanOutListeners sends to Updates - dropping and
+// coalescing logic live entirely here so fanOutListeners in receiver.go
+// stays policy-agnostic.
+func (listener *Listener) deliver(typ Type) {
+	if listener.options.Filter != nil && !listener.options.Filter(typ) {
+		return
+	}
+
+	switch listener.options.Overflow {
+	case DropNewest:
+		select {
+		case listener.Updates <- typ:
+		default:
+			listener.recordDrop()
+		}
+
+	case DropOldest:
+		for {
+			select {
+			case listener.Updates <- typ:
+				return
+			default:
+			}
+
+			select {
+			case <-listener.Updates:
+				listener.recordDrop()
+			default:
+				// Updates was drained concurrently by the consumer; retry
+				// the send now that there may be room.
+			}
+		}
+
+	case Coalesce:
+		listener.deliverCoalesced(typ)
+
+	default: // Block
+		listener.Updates <- typ
+	}
+}
+
+func (listener *Listener) recordDrop() {
+	listener.mu.Lock()
+	defer listener.mu.Unlock()
+
+	listener.recordDropLocked()
+}
+
+func (listener *Listener) recordDropLocked() {
+	listener.dropped++
+	if listener.options.Strict {
+		listener.deactivateLocked(ErrListenerOverflow)
+	}
+}
+
+// deliverCoalesced holds listener.mu for its entire body, including the
+// pumpTrigger send, so it can never race Close's close(listener.pumpTrigger)
+// under the same lock - sending on a closed channel would panic, not just
+// drop silently.
+func (listener *Listener) deliverCoalesced(typ Type) {
+	key := listener.options.CoalesceKey(typ)
+
+	listener.mu.Lock()
+	defer listener.mu.Unlock()
+
+	if !listener.isActive {
+		return
+	}
+
+	if _, pending := listener.coalesced[key]; pending {
+		// Replacing a pending update is what Coalesce is for, not an
+		// overflow, so it is counted but never closes a Strict listener.
+		listener.dropped++
+		listener.coalesced[key] = typ
+		return
+	}
+	listener.coalesced[key] = typ
+
+	select {
+	case listener.pumpTrigger <- struct{}{}:
+	default:
+	}
+}
+
+// coalescePump drains listener.coalesced into Updates, one key at a time,
+// whenever deliverCoalesced signals new work. It runs for the lifetime of a
+// Coalesce-policy listener and exits once the listener is closed.
+func (listener *Listener) coalescePump() {
+	for range listener.pumpTrigger {
+		for {
+			listener.mu.Lock()
+			var key string
+			var typ Type
+			found := false
+			for k, v := range listener.coalesced {
+				key, typ, found = k, v, true
+				break
+			}
+			if found {
+				delete(listener.coalesced, key)
+			}
+			listener.mu.Unlock()
+
+			if !found {
+				break
+			}
+			listener.Updates <- typ
+		}
+	}
+}
+
+// listenerStore tracks the listeners registered on a Client so the receive
+// loop can fan an update out to all of them and periodically garbage
+// collect the ones that have been closed.
+type listenerStore struct {
+	mu        sync.Mutex
+	listeners []*Listener
+}
+
+func newListenerStore() *listenerStore {
+	return &listenerStore{}
+}
+
+func (s *listenerStore) Add(listener *Listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.listeners = append(s.listeners, listener)
+}
+
+func (s *listenerStore) Listeners() []*Listener {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	listeners := make([]*Listener, len(s.listeners))
+	copy(listeners, s.listeners)
+	return listeners
+}
+
+func (s *listenerStore) gc() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	active := s.listeners[:0]
+	for _, listener := range s.listeners {
+		if listener.IsActive() {
+			active = append(active, listener)
+		}
+	}
+	s.listeners = active
+}
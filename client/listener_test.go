@@ -0,0 +1,153 @@
+package client
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// testUpdate is a minimal Type implementation for exercising Listener
+// without depending on any real TDLib update type.
+type testUpdate struct {
+	key string
+}
+
+func (u testUpdate) GetType() string { return u.key }
+
+func coalesceKey(typ Type) string {
+	return typ.(testUpdate).key
+}
+
+func TestListenerDropNewest(t *testing.T) {
+	listener := newListener(ListenerOptions{BufferSize: 2, Overflow: DropNewest})
+
+	listener.deliver(testUpdate{"a"})
+	listener.deliver(testUpdate{"b"})
+	listener.deliver(testUpdate{"c"}) // dropped: buffer already full
+
+	if got := listener.Dropped(); got != 1 {
+		t.Fatalf("Dropped() = %d, want 1", got)
+	}
+	if got := len(listener.Updates); got != 2 {
+		t.Fatalf("len(Updates) = %d, want 2", got)
+	}
+
+	first := <-listener.Updates
+	if first.(testUpdate).key != "a" {
+		t.Fatalf("expected oldest update to survive, got %v", first)
+	}
+}
+
+func TestListenerDropOldest(t *testing.T) {
+	listener := newListener(ListenerOptions{BufferSize: 2, Overflow: DropOldest})
+
+	listener.deliver(testUpdate{"a"})
+	listener.deliver(testUpdate{"b"})
+	listener.deliver(testUpdate{"c"}) // "a" is dropped to make room
+
+	if got := listener.Dropped(); got != 1 {
+		t.Fatalf("Dropped() = %d, want 1", got)
+	}
+
+	first := <-listener.Updates
+	if first.(testUpdate).key != "b" {
+		t.Fatalf("expected oldest update to be dropped, got %v", first)
+	}
+	second := <-listener.Updates
+	if second.(testUpdate).key != "c" {
+		t.Fatalf("expected newest update to survive, got %v", second)
+	}
+}
+
+func TestListenerCoalesce(t *testing.T) {
+	listener := newListener(ListenerOptions{
+		BufferSize:  10,
+		Overflow:    Coalesce,
+		CoalesceKey: coalesceKey,
+	})
+	defer listener.Close()
+
+	listener.deliver(testUpdate{"chat-1"})
+	listener.deliver(testUpdate{"chat-2"})
+
+	seen := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case update := <-listener.Updates:
+			seen[update.(testUpdate).key] = true
+		case <-time.After(time.Second):
+			t.Fatalf("expected updates for 2 distinct keys, got %v", seen)
+		}
+	}
+
+	if !seen["chat-1"] || !seen["chat-2"] {
+		t.Fatalf("expected both distinct keys to be delivered, got %v", seen)
+	}
+}
+
+// TestListenerCoalesceBurstDelivered delivers the same key twice with nothing
+// draining Updates in between. Whether that lands as one or two deliveries
+// depends on whether coalescePump's goroutine happened to drain between the
+// two calls, so this only asserts the key is delivered, not an exact count.
+func TestListenerCoalesceBurstDelivered(t *testing.T) {
+	listener := newListener(ListenerOptions{
+		BufferSize:  10,
+		Overflow:    Coalesce,
+		CoalesceKey: coalesceKey,
+	})
+	defer listener.Close()
+
+	listener.deliver(testUpdate{"chat-1"})
+	listener.deliver(testUpdate{"chat-1"})
+
+	select {
+	case update := <-listener.Updates:
+		if update.(testUpdate).key != "chat-1" {
+			t.Fatalf("unexpected update: %v", update)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("coalesced update was never delivered")
+	}
+}
+
+func TestListenerStrictClosesOnOverflow(t *testing.T) {
+	listener := newListener(ListenerOptions{BufferSize: 1, Overflow: DropNewest, Strict: true})
+
+	listener.deliver(testUpdate{"a"})
+	listener.deliver(testUpdate{"b"}) // overflow under Strict: closes the listener
+
+	if listener.IsActive() {
+		t.Fatal("expected listener to be deactivated after a Strict overflow")
+	}
+	if err := listener.Err(); err != ErrListenerOverflow {
+		t.Fatalf("Err() = %v, want ErrListenerOverflow", err)
+	}
+}
+
+// TestListenerCoalesceCloseRace hammers deliverCoalesced concurrently with
+// Close: deliverCoalesced must never send on pumpTrigger after Close has
+// closed it.
+func TestListenerCoalesceCloseRace(t *testing.T) {
+	listener := newListener(ListenerOptions{
+		BufferSize:  10,
+		Overflow:    Coalesce,
+		CoalesceKey: coalesceKey,
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			listener.deliver(testUpdate{"chat-1"})
+		}(i)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		listener.Close()
+	}()
+
+	wg.Wait()
+}
@@ -0,0 +1,153 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics holds the Prometheus collectors registered by WithMetrics. Every
+// place that touches it first checks client.metrics != nil, so a client
+// built without WithMetrics pays no cost beyond that check.
+type metrics struct {
+	sentTotal           *prometheus.CounterVec
+	sendLatency         *prometheus.HistogramVec
+	catchersOutstanding prometheus.Gauge
+	listenersActive     prometheus.Gauge
+	listenerQueueLength *prometheus.GaugeVec
+	receivedTotal       *prometheus.CounterVec
+	listenerDropped     *prometheus.CounterVec
+	inboundDropped      prometheus.Counter
+	fanoutDropped       prometheus.Counter
+}
+
+// WithMetrics registers an opt-in set of Prometheus collectors under
+// namespace on reg and wires client to update them: a counter of sent
+// requests labeled by @type, a histogram of Send round-trip latency labeled
+// by @type and outcome (ok/timeout/tdlib_error), a gauge of entries
+// currently outstanding in catchersStore, a gauge of active listeners and
+// per-listener queue-length gauge, a counter of received updates labeled by
+// @type, a counter of updates dropped per listener due to overflow, and
+// counters for updates dropped at the receiver and catcher/fanout handoff.
+//
+// Collectors are registered with reg via Register, not MustRegister: if
+// another Client already registered the same namespace on reg, the existing
+// collectors are reused instead of panicking, so multiple Clients can share
+// one Registerer and namespace - their metrics are then aggregated, not kept
+// per-client.
+func WithMetrics(reg prometheus.Registerer, namespace string) Option {
+	return func(client *Client) {
+		m := &metrics{
+			sentTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "requests_sent_total",
+				Help:      "Number of TDLib requests sent, labeled by @type.",
+			}, []string{"type"}),
+			sendLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "send_duration_seconds",
+				Help:      "Client.Send round-trip latency, labeled by @type and outcome.",
+			}, []string{"type", "outcome"}),
+			catchersOutstanding: prometheus.NewGauge(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "catchers_outstanding",
+				Help:      "Number of Send/SendCtx calls currently awaiting a response.",
+			}),
+			listenersActive: prometheus.NewGauge(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "listeners_active",
+				Help:      "Number of active listeners returned by GetListener.",
+			}),
+			listenerQueueLength: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "listener_queue_length",
+				Help:      "Current number of buffered updates in a listener's Updates channel.",
+			}, []string{"listener"}),
+			receivedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "updates_received_total",
+				Help:      "Number of updates received from TDLib, labeled by @type.",
+			}, []string{"type"}),
+			listenerDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "listener_updates_dropped_total",
+				Help:      "Number of updates dropped because a listener's queue overflowed.",
+			}, []string{"listener"}),
+			inboundDropped: prometheus.NewCounter(prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "inbound_dropped_total",
+				Help:      "Number of responses dropped because a client's inbound queue was full.",
+			}),
+			fanoutDropped: prometheus.NewCounter(prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "fanout_dropped_total",
+				Help:      "Number of updates dropped because the catcher-to-listener handoff queue was full.",
+			}),
+		}
+
+		m.sentTotal = mustRegisterOrReuse(reg, m.sentTotal).(*prometheus.CounterVec)
+		m.sendLatency = mustRegisterOrReuse(reg, m.sendLatency).(*prometheus.HistogramVec)
+		m.catchersOutstanding = mustRegisterOrReuse(reg, m.catchersOutstanding).(prometheus.Gauge)
+		m.listenersActive = mustRegisterOrReuse(reg, m.listenersActive).(prometheus.Gauge)
+		m.listenerQueueLength = mustRegisterOrReuse(reg, m.listenerQueueLength).(*prometheus.GaugeVec)
+		m.receivedTotal = mustRegisterOrReuse(reg, m.receivedTotal).(*prometheus.CounterVec)
+		m.listenerDropped = mustRegisterOrReuse(reg, m.listenerDropped).(*prometheus.CounterVec)
+		m.inboundDropped = mustRegisterOrReuse(reg, m.inboundDropped).(prometheus.Counter)
+		m.fanoutDropped = mustRegisterOrReuse(reg, m.fanoutDropped).(prometheus.Counter)
+
+		client.metrics = m
+		client.Use(metricsInterceptor(m))
+	}
+}
+
+// mustRegisterOrReuse registers collector on reg, returning it. If an
+// identical collector was already registered (e.g. by another Client sharing
+// reg and namespace), it returns the existing one instead of panicking.
+func mustRegisterOrReuse(reg prometheus.Registerer, collector prometheus.Collector) prometheus.Collector {
+	if err := reg.Register(collector); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			return are.ExistingCollector
+		}
+		panic(err)
+	}
+	return collector
+}
+
+// metricsInterceptor counts every call by @type and records its latency and
+// outcome. It relies on tdlibError from interceptor.go to tell a TDLib-level
+// "error" response apart from a successful one.
+func metricsInterceptor(m *metrics) Interceptor {
+	return func(next Invoker) Invoker {
+		return func(ctx context.Context, req Request) (*Response, error) {
+			start := time.Now()
+			m.sentTotal.WithLabelValues(req.Type).Inc()
+
+			resp, err := next(ctx, req)
+
+			m.sendLatency.WithLabelValues(req.Type, sendOutcome(resp, err)).Observe(time.Since(start).Seconds())
+
+			return resp, err
+		}
+	}
+}
+
+func sendOutcome(resp *Response, err error) string {
+	if err != nil {
+		return "timeout"
+	}
+	if _, ok := tdlibError(resp); ok {
+		return "tdlib_error"
+	}
+	return "ok"
+}
+
+// listenerLabel identifies a listener for per-listener metrics. Listener
+// carries no public identifier, so its pointer address is used - stable for
+// the listener's lifetime and unique among concurrently active listeners.
+func listenerLabel(listener *Listener) string {
+	return fmt.Sprintf("%p", listener)
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// TestWithMetricsPromhttpWiring exercises the intended wiring: a Client's
+// collectors registered on reg are scraped through promhttp like any other
+// Prometheus-instrumented service.
+func TestWithMetricsPromhttpWiring(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	client := &Client{}
+	WithMetrics(reg, "tdlib_test")(client)
+
+	client.metrics.sentTotal.WithLabelValues("getChat").Inc()
+
+	server := httptest.NewServer(promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("scraping metrics endpoint: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body strings.Builder
+	buf := make([]byte, 4096)
+	for {
+		n, err := resp.Body.Read(buf)
+		body.Write(buf[:n])
+		if err != nil {
+			break
+		}
+	}
+
+	if !strings.Contains(body.String(), "tdlib_test_requests_sent_total") {
+		t.Fatalf("scraped output missing expected metric:\n%s", body.String())
+	}
+}
+
+// TestWithMetricsSharedRegisterer verifies two Clients sharing a Registerer
+// and namespace no longer panic on the second WithMetrics call, and instead
+// aggregate onto the same collectors.
+func TestWithMetricsSharedRegisterer(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	first := &Client{}
+	WithMetrics(reg, "tdlib_shared")(first)
+
+	second := &Client{}
+	WithMetrics(reg, "tdlib_shared")(second)
+
+	first.metrics.sentTotal.WithLabelValues("getChat").Inc()
+	second.metrics.sentTotal.WithLabelValues("getChat").Inc()
+
+	count := counterValue(t, first.metrics.sentTotal.WithLabelValues("getChat"))
+	if count != 2 {
+		t.Fatalf("expected shared collector to observe both increments, got %v", count)
+	}
+}
+
+func counterValue(t *testing.T, c prometheus.Counter) float64 {
+	t.Helper()
+
+	var m dto.Metric
+	if err := c.Write(&m); err != nil {
+		t.Fatalf("writing counter metric: %v", err)
+	}
+	return m.GetCounter().GetValue()
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCatcherReaped is returned by Send/SendCtx when the background reaper
+// forcibly removed the call's catcher entry because its deadline (plus
+// grace) had passed without a response - e.g. because the caller's
+// goroutine was killed, a panic unwound before Send's own cleanup ran, or
+// TDLib simply never replied and the caller ignored ctx.
+var ErrCatcherReaped = errors.New("client: catcher reaped after deadline")
+
+// reapedResponse is the sentinel value the reaper pushes into a stale
+// catcher's channel so a still-waiting Send/SendCtx wakes up with
+// ErrCatcherReaped instead of blocking until catchTimeout.
+var reapedResponse = &Response{}
+
+const (
+	defaultReaperInterval = 30 * time.Second
+	defaultReaperGrace    = 5 * time.Second
+)
+
+// WithReaperInterval sets how often the background reaper scans
+// catchersStore for entries past their deadline. Defaults to 30s.
+func WithReaperInterval(interval time.Duration) Option {
+	return func(client *Client) {
+		client.reaperInterval = interval
+	}
+}
+
+// WithReaperGrace sets how long past its deadline a catcher entry is
+// allowed to sit before the reaper removes it. Defaults to 5s; keep it well
+// above normal network jitter so the reaper never beats a response that is
+// already on its way in.
+func WithReaperGrace(grace time.Duration) Option {
+	return func(client *Client) {
+		client.reaperGrace = grace
+	}
+}
+
+// catcherEntry is what Client.catchersStore stores per in-flight
+// Send/SendCtx call. deadline is mutable (via RefreshCatcher) so it is kept
+// behind atomic.Value rather than as a plain field.
+type catcherEntry struct {
+	ch           chan *Response
+	registeredAt time.Time
+	deadline     atomic.Value // time.Time
+}
+
+func (entry *catcherEntry) setDeadline(t time.Time) {
+	entry.deadline.Store(t)
+}
+
+func (entry *catcherEntry) getDeadline() (time.Time, bool) {
+	t, ok := entry.deadline.Load().(time.Time)
+	return t, ok
+}
+
+// RefreshCatcher extends the deadline of the in-flight Send/SendCtx call
+// identified by extra (its Request.Extra) to time.Now().Add(extend),
+// keeping the background reaper from treating it as stale. It is meant for
+// TDLib operations known to legitimately run long. It reports whether a
+// matching in-flight call was found.
+func (client *Client) RefreshCatcher(extra string, extend time.Duration) bool {
+	value, ok := client.catchersStore.Load(extra)
+	if !ok {
+		return false
+	}
+
+	value.(*catcherEntry).setDeadline(time.Now().Add(extend))
+	return true
+}
+
+// reapStaleCatchers runs for the lifetime of the client, periodically
+// removing catchersStore entries whose deadline plus reaperGrace has
+// passed. Forced removal never closes the entry's channel - it only ever
+// does a non-blocking send of reapedResponse - so it can never race a
+// concurrent, legitimate send from processInbound into a closed channel.
+func (client *Client) reapStaleCatchers() {
+	ticker := time.NewTicker(client.reaperInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-client.done:
+			return
+		case <-ticker.C:
+			client.reapOnce()
+		}
+	}
+}
+
+func (client *Client) reapOnce() {
+	now := time.Now()
+
+	client.catchersStore.Range(func(key, value interface{}) bool {
+		entry := value.(*catcherEntry)
+
+		deadline, ok := entry.getDeadline()
+		if !ok || now.Before(deadline.Add(client.reaperGrace)) {
+			return true
+		}
+
+		client.catchersStore.Delete(key)
+
+		select {
+		case entry.ch <- reapedResponse:
+		default:
+			// A real response (or another reap) already filled the
+			// buffered slot; nothing left to wake up.
+		}
+
+		return true
+	})
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestClient(grace time.Duration) *Client {
+	return &Client{
+		catchersStore: &sync.Map{},
+		reaperGrace:   grace,
+	}
+}
+
+// TestRefreshCatcherExtendsDeadline verifies RefreshCatcher's push-out is
+// actually observed by a later read of the entry's deadline, and that
+// reapOnce leaves a refreshed entry alone even past its original deadline.
+func TestRefreshCatcherExtendsDeadline(t *testing.T) {
+	client := newTestClient(0)
+
+	entry := &catcherEntry{ch: make(chan *Response, 1)}
+	entry.setDeadline(time.Now().Add(-time.Minute))
+	client.catchersStore.Store("extra", entry)
+
+	if !client.RefreshCatcher("extra", time.Minute) {
+		t.Fatal("RefreshCatcher reported no matching entry")
+	}
+
+	client.reapOnce()
+
+	if _, ok := client.catchersStore.Load("extra"); !ok {
+		t.Fatal("reapOnce removed an entry whose deadline was refreshed into the future")
+	}
+}
+
+// TestReapOnceRemovesStaleEntry verifies a catcher whose deadline plus grace
+// has passed is removed and its waiter is woken with reapedResponse, rather
+// than left to leak in catchersStore forever.
+func TestReapOnceRemovesStaleEntry(t *testing.T) {
+	client := newTestClient(0)
+
+	entry := &catcherEntry{ch: make(chan *Response, 1)}
+	entry.setDeadline(time.Now().Add(-time.Minute))
+	client.catchersStore.Store("extra", entry)
+
+	client.reapOnce()
+
+	if _, ok := client.catchersStore.Load("extra"); ok {
+		t.Fatal("reapOnce left a stale entry in catchersStore")
+	}
+
+	select {
+	case resp := <-entry.ch:
+		if resp != reapedResponse {
+			t.Fatalf("expected reapedResponse, got %v", resp)
+		}
+	default:
+		t.Fatal("reapOnce did not wake the waiting entry")
+	}
+}
+
+// TestRefreshCatcherUnknownExtra verifies RefreshCatcher reports false for a
+// call that already completed (or never existed) instead of silently doing
+// nothing.
+func TestRefreshCatcherUnknownExtra(t *testing.T) {
+	client := newTestClient(0)
+
+	if client.RefreshCatcher("missing", time.Minute) {
+		t.Fatal("RefreshCatcher reported success for an unknown extra")
+	}
+}
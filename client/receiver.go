@@ -0,0 +1,161 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// inboundQueueSize bounds the per-client queue the receiver hands raw
+// responses off to. fanoutQueueSize bounds the queue between catcher
+// matching and listener delivery - kept separate so a blocking listener
+// never backs up catcher delivery for the same client.
+const (
+	inboundQueueSize = 1000
+	fanoutQueueSize  = 1000
+)
+
+// receiver owns the single background goroutine that pumps the TDLib
+// Receive() loop and routes each response to the Client it belongs to.
+type receiver struct {
+	start sync.Once
+
+	mu      sync.RWMutex
+	clients map[int]*Client
+
+	// updatesTimeout is fixed at the value configured on whichever client
+	// causes the loop to start.
+	updatesTimeout time.Duration
+}
+
+var globalReceiver = &receiver{
+	clients: make(map[int]*Client),
+}
+
+func (r *receiver) register(client *Client) {
+	r.mu.Lock()
+	r.clients[client.jsonClient.id] = client
+	r.mu.Unlock()
+
+	r.start.Do(func() {
+		r.updatesTimeout = client.updatesTimeout
+		go r.run()
+	})
+}
+
+// unregister removes client from the registry and closes its inbound
+// queue. Both happen under the write lock so they can never interleave
+// with a dispatch that has already looked client up under the read lock:
+// dispatch either completes its send before unregister's Lock() is
+// granted, or it never finds client at all.
+func (r *receiver) unregister(client *Client) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.clients, client.jsonClient.id)
+	close(client.inbound)
+}
+
+func (r *receiver) run() {
+	for {
+		resp, err := Receive(r.updatesTimeout)
+		if err != nil {
+			continue
+		}
+		r.dispatch(resp)
+	}
+}
+
+// dispatch hands resp to the client it belongs to. The lookup and the send
+// happen under the same read lock as unregister's write lock, so a send
+// here can never race unregister's close of target.inbound.
+func (r *receiver) dispatch(resp *Response) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	target, ok := r.clients[resp.ClientId]
+	if !ok {
+		return
+	}
+
+	select {
+	case target.inbound <- resp:
+	default:
+		if target.metrics != nil {
+			target.metrics.inboundDropped.Inc()
+		}
+	}
+}
+
+// processInbound matches responses against catchersStore and forwards
+// updates to fanOutListeners via client.fanout. It never calls
+// listener.deliver itself, so a listener blocked under the Block overflow
+// policy cannot delay catcher delivery - or processInbound draining
+// client.inbound - for this same client.
+func (client *Client) processInbound() {
+	defer close(client.fanout)
+
+	for resp := range client.inbound {
+		if resp.Extra != "" {
+			if value, ok := client.catchersStore.Load(resp.Extra); ok {
+				select {
+				case value.(*catcherEntry).ch <- resp:
+				default:
+					// The reaper already abandoned this entry and filled
+					// its one buffered slot with reapedResponse - nobody
+					// is left to drain a second send.
+				}
+			}
+		}
+
+		typ, err := UnmarshalType(resp.Data)
+		if err != nil {
+			continue
+		}
+
+		if client.metrics != nil {
+			client.metrics.receivedTotal.WithLabelValues(typ.GetType()).Inc()
+		}
+
+		select {
+		case client.fanout <- typ:
+		default:
+			if client.metrics != nil {
+				client.metrics.fanoutDropped.Inc()
+			}
+		}
+	}
+}
+
+// fanOutListeners delivers updates queued by processInbound to every
+// registered listener, applying each one's OverflowPolicy. It runs on its
+// own goroutine so a listener using the default, blocking Block policy
+// only ever stalls other listeners on the same client, never catcher
+// delivery.
+func (client *Client) fanOutListeners() {
+	for typ := range client.fanout {
+		listeners := client.listenerStore.Listeners()
+		if client.metrics != nil {
+			client.metrics.listenersActive.Set(float64(len(listeners)))
+		}
+
+		needGc := false
+		for _, listener := range listeners {
+			if !listener.IsActive() {
+				needGc = true
+				continue
+			}
+
+			dropped := listener.Dropped()
+			listener.deliver(typ)
+			if client.metrics != nil {
+				client.metrics.listenerQueueLength.WithLabelValues(listenerLabel(listener)).Set(float64(len(listener.Updates)))
+				if d := listener.Dropped() - dropped; d > 0 {
+					client.metrics.listenerDropped.WithLabelValues(listenerLabel(listener)).Add(float64(d))
+				}
+			}
+		}
+		if needGc {
+			client.listenerStore.gc()
+		}
+	}
+}
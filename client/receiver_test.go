@@ -0,0 +1,53 @@
+package client
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestReceiverConcurrentRegisterDispatchUnregister registers N clients, then
+// hammers dispatch concurrently with unregister under -race to exercise
+// the locking invariant dispatch and unregister rely on: a dispatch that has
+// already looked a client up can never race unregister's close of that
+// client's inbound channel.
+func TestReceiverConcurrentRegisterDispatchUnregister(t *testing.T) {
+	const clientCount = 50
+	const messagesPerClient = 200
+
+	r := &receiver{clients: make(map[int]*Client)}
+
+	clients := make([]*Client, clientCount)
+	for i := range clients {
+		clients[i] = &Client{
+			jsonClient: &JsonClient{id: i},
+			inbound:    make(chan *Response, inboundQueueSize),
+		}
+		r.register(clients[i])
+	}
+
+	var wg sync.WaitGroup
+
+	for _, c := range clients {
+		c := c
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			for i := 0; i < messagesPerClient; i++ {
+				r.dispatch(&Response{ClientId: c.jsonClient.id})
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+			for range c.inbound {
+			}
+		}()
+	}
+
+	for _, c := range clients {
+		r.unregister(c)
+	}
+
+	wg.Wait()
+}
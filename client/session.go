@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSessionNotFound is returned by a SessionStore's Get when key has no
+// value.
+var ErrSessionNotFound = errors.New("client: session key not found")
+
+// EventType distinguishes the kind of change a SessionStore.Watch event
+// represents.
+type EventType int
+
+const (
+	EventPut EventType = iota
+	EventDelete
+)
+
+// Event is a single change observed on a key under a watched prefix.
+type Event struct {
+	Type  EventType
+	Key   string
+	Value []byte
+}
+
+// SessionStore persists a checkpoint of a Client's tdlib_db directory
+// somewhere other than local disk, so a bot's session - including the phone
+// number and encryption key TDLib writes into tdlib_db once authorization
+// has run - can be handed off between replicas instead of being pinned to
+// one machine's disk.
+//
+// Configure it with WithSessionStore, WithSessionKey (a stable identifier
+// for the account, e.g. its phone number) and WithSessionDirectory (the
+// same path passed to TDLib as database_directory). With all three set,
+// Client.Auth restores the latest checkpoint into that directory before
+// authorizing and uploads a fresh one once authorization succeeds, and Stop
+// uploads a final checkpoint on graceful shutdown. Watch exists so a
+// standby replica can detect that a checkpoint changed without polling,
+// though nothing in this package consumes it yet.
+type SessionStore interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, value []byte) error
+	Delete(ctx context.Context, key string) error
+	Watch(ctx context.Context, prefix string) (<-chan Event, error)
+}
+
+// WithSessionStore sets the SessionStore Client.Auth and Stop checkpoint
+// the tdlib_db directory through. See SessionStore's doc comment - it also
+// needs WithSessionKey and WithSessionDirectory to take effect.
+func WithSessionStore(store SessionStore) Option {
+	return func(client *Client) {
+		client.sessionStore = store
+	}
+}
+
+// WithSessionKey sets the identifier a checkpoint is stored under -
+// typically the account's phone number, stable across restarts and unique
+// among accounts sharing the same SessionStore.
+func WithSessionKey(key string) Option {
+	return func(client *Client) {
+		client.sessionKey = key
+	}
+}
+
+// WithSessionDirectory sets the local path Client.Auth restores a
+// checkpoint into and checkpoints from - the same directory configured as
+// TDLib's database_directory.
+func WithSessionDirectory(dir string) Option {
+	return func(client *Client) {
+		client.dbDirectory = dir
+	}
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// checkpointShutdownTimeout bounds the final checkpoint Stop uploads.
+const checkpointShutdownTimeout = 10 * time.Second
+
+const dbArchiveKeySuffix = "/tdlib_db.tar.gz"
+
+// restoreSession downloads the tdlib_db checkpoint stored under
+// client.sessionKey, if any, and extracts it into client.dbDirectory. A
+// missing checkpoint is not an error - it just means no replica has
+// checkpointed this session yet.
+func (client *Client) restoreSession(ctx context.Context) error {
+	data, err := client.sessionStore.Get(ctx, client.sessionKey+dbArchiveKeySuffix)
+	if errors.Is(err, ErrSessionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("client: restore session: %w", err)
+	}
+
+	if err := extractTarGz(data, client.dbDirectory); err != nil {
+		return fmt.Errorf("client: restore session: %w", err)
+	}
+	return nil
+}
+
+// checkpointSession tars and gzips client.dbDirectory and uploads it to
+// client.sessionStore under client.sessionKey, so another replica (or this
+// one, after a restart) can restore it via restoreSession.
+func (client *Client) checkpointSession(ctx context.Context) error {
+	data, err := createTarGz(client.dbDirectory)
+	if err != nil {
+		return fmt.Errorf("client: checkpoint session: %w", err)
+	}
+
+	if err := client.sessionStore.Put(ctx, client.sessionKey+dbArchiveKeySuffix, data); err != nil {
+		return fmt.Errorf("client: checkpoint session: %w", err)
+	}
+	return nil
+}
+
+func createTarGz(dir string) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = rel
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func extractTarGz(data []byte, dir string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, header.Name)
+		if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+			return err
+		}
+
+		if err := writeExtractedFile(target, header, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func writeExtractedFile(target string, header *tar.Header, tr *tar.Reader) error {
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, tr)
+	return err
+}
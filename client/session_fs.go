@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// FileSessionStore is a SessionStore that keeps artifacts as files under
+// Dir, preserving the single-machine behavior a Client had before
+// SessionStore existed. A Client has no SessionStore unless one is set with
+// WithSessionStore. Watch is unsupported - the local filesystem
+// has no other writer to watch for in this library's use case - and
+// returns a channel that is closed without ever reporting an event.
+type FileSessionStore struct {
+	Dir string
+}
+
+func NewFileSessionStore(dir string) *FileSessionStore {
+	return &FileSessionStore{Dir: dir}
+}
+
+func (store *FileSessionStore) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(store.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrSessionNotFound
+	}
+	return data, err
+}
+
+func (store *FileSessionStore) Put(ctx context.Context, key string, value []byte) error {
+	if err := os.MkdirAll(filepath.Dir(store.path(key)), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(store.path(key), value, 0o600)
+}
+
+func (store *FileSessionStore) Delete(ctx context.Context, key string) error {
+	err := os.Remove(store.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (store *FileSessionStore) Watch(ctx context.Context, prefix string) (<-chan Event, error) {
+	events := make(chan Event)
+	close(events)
+	return events, nil
+}
+
+func (store *FileSessionStore) path(key string) string {
+	return filepath.Join(store.Dir, key)
+}